@@ -0,0 +1,3 @@
+// Package liveshare is a client for the Visual Studio Live Share service,
+// used by the gh cli to forward ports and run commands inside a codespace.
+package liveshare
@@ -0,0 +1,158 @@
+package liveshare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// eventMethods lists the inbound notification methods that Connect
+// routes to the session's event dispatcher.
+var eventMethods = []string{
+	"serverSharing.sharingStarted",
+	"serverSharing.sharingSucceeded",
+	"serverSharing.sharingStopped",
+	"serverSharing.serverChanged",
+	"IEnvironmentConfigurationService.progressUpdate",
+}
+
+// EventType identifies the category of an Event delivered to a
+// subscriber. It is a bitmask so a subscriber can listen for more than
+// one category on a single channel.
+type EventType uint8
+
+const (
+	// PortEvents covers serverSharing.sharingStarted, sharingSucceeded,
+	// and sharingStopped notifications.
+	PortEvents EventType = 1 << iota
+	// HostEvents covers serverSharing.serverChanged notifications.
+	HostEvents
+	// RebuildEvents covers IEnvironmentConfigurationService.progressUpdate
+	// notifications.
+	RebuildEvents
+	// ReconnectEvents covers Reconnecting/Reconnected transitions
+	// reported by the session's own connection watcher, not the host.
+	ReconnectEvents
+)
+
+// HostNotification reports a change to the shared-server host, as
+// delivered by serverSharing.serverChanged.
+type HostNotification struct {
+	ChangeKind string `json:"changeKind"`
+}
+
+// RebuildNotification reports progress of a dev container rebuild, as
+// delivered by IEnvironmentConfigurationService.progressUpdate.
+type RebuildNotification struct {
+	Message  string `json:"message"`
+	Progress int    `json:"progress"`
+}
+
+// Event is a single notification from the host, or a reconnect
+// transition reported by the session itself, decoded into whichever
+// typed field matches its Type.
+type Event struct {
+	Type      EventType
+	Port      *PortNotification
+	Host      *HostNotification
+	Rebuild   *RebuildNotification
+	Reconnect *ReconnectNotification
+}
+
+// subscriberBufferSize bounds how many events a slow subscriber may
+// fall behind by before further events are dropped for it.
+const subscriberBufferSize = 16
+
+type subscriber struct {
+	mask   EventType
+	eventc chan Event
+}
+
+// Subscribe registers interest in events matching mask, returning a
+// channel of matching events and an unsubscribe function that must be
+// called to release the subscription. The channel is closed once
+// unsubscribe is called, whether explicitly or because ctx was
+// canceled. A subscriber that falls behind has events dropped for it
+// rather than blocking delivery to other subscribers; dropped counts
+// are reported in Stats().DroppedEvents so a caller can detect a slow
+// subscriber.
+func (s *Session) Subscribe(ctx context.Context, mask EventType) (<-chan Event, func()) {
+	sub := &subscriber{mask: mask, eventc: make(chan Event, subscriberBufferSize)}
+
+	s.subsMu.Lock()
+	s.subs[sub] = struct{}{}
+	s.subsMu.Unlock()
+
+	stop := make(chan struct{})
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			close(stop)
+			s.subsMu.Lock()
+			delete(s.subs, sub)
+			s.subsMu.Unlock()
+			close(sub.eventc)
+		})
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			unsubscribe()
+		case <-stop:
+		}
+	}()
+	return sub.eventc, unsubscribe
+}
+
+func (s *Session) publish(evt Event) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	for sub := range s.subs {
+		if sub.mask&evt.Type == 0 {
+			continue
+		}
+		select {
+		case sub.eventc <- evt:
+		default:
+			s.recordDroppedEvent()
+		}
+	}
+}
+
+// decodeEvent translates an inbound notification into an Event,
+// reporting ok=false for methods that aren't published events.
+func decodeEvent(method string, params *json.RawMessage) (evt Event, ok bool, err error) {
+	switch method {
+	case "serverSharing.sharingStarted", "serverSharing.sharingSucceeded", "serverSharing.sharingStopped":
+		var n PortNotification
+		if err := unmarshalParams(method, params, &n); err != nil {
+			return Event{}, false, err
+		}
+		return Event{Type: PortEvents, Port: &n}, true, nil
+	case "serverSharing.serverChanged":
+		var n HostNotification
+		if err := unmarshalParams(method, params, &n); err != nil {
+			return Event{}, false, err
+		}
+		return Event{Type: HostEvents, Host: &n}, true, nil
+	case "IEnvironmentConfigurationService.progressUpdate":
+		var n RebuildNotification
+		if err := unmarshalParams(method, params, &n); err != nil {
+			return Event{}, false, err
+		}
+		return Event{Type: RebuildEvents, Rebuild: &n}, true, nil
+	default:
+		return Event{}, false, nil
+	}
+}
+
+func unmarshalParams(method string, params *json.RawMessage, v interface{}) error {
+	if params == nil {
+		return nil
+	}
+	if err := json.Unmarshal(*params, v); err != nil {
+		return fmt.Errorf("error decoding %s params: %w", method, err)
+	}
+	return nil
+}
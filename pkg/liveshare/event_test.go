@@ -0,0 +1,106 @@
+package liveshare
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSessionSubscribe(t *testing.T) {
+	testServer, session, err := makeMockSession()
+	if err != nil {
+		t.Fatalf("error creating mock session: %v", err)
+	}
+	defer testServer.Close()
+
+	events, unsubscribe := session.Subscribe(context.Background(), PortEvents)
+	defer unsubscribe()
+
+	notification := PortNotification{Port: 2222, ChangeKind: PortChangeKindStart}
+	if err := testServer.Notify("serverSharing.sharingSucceeded", notification); err != nil {
+		t.Fatalf("error notifying client: %v", err)
+	}
+
+	select {
+	case evt := <-events:
+		if evt.Type != PortEvents {
+			t.Errorf("unexpected event type: %v", evt.Type)
+		}
+		if evt.Port == nil || evt.Port.Port != notification.Port {
+			t.Errorf("unexpected port notification: %+v", evt.Port)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
+func TestSessionSubscribeIgnoresUnmaskedEvents(t *testing.T) {
+	testServer, session, err := makeMockSession()
+	if err != nil {
+		t.Fatalf("error creating mock session: %v", err)
+	}
+	defer testServer.Close()
+
+	events, unsubscribe := session.Subscribe(context.Background(), HostEvents)
+	defer unsubscribe()
+
+	if err := testServer.Notify("serverSharing.sharingSucceeded", PortNotification{Port: 2222}); err != nil {
+		t.Fatalf("error notifying client: %v", err)
+	}
+
+	select {
+	case evt := <-events:
+		t.Fatalf("unexpected event delivered: %+v", evt)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSessionPublishRecordsDroppedEvents(t *testing.T) {
+	testServer, session, err := makeMockSession()
+	if err != nil {
+		t.Fatalf("error creating mock session: %v", err)
+	}
+	defer testServer.Close()
+
+	_, unsubscribe := session.Subscribe(context.Background(), PortEvents)
+	defer unsubscribe()
+
+	notification := PortNotification{Port: 2222, ChangeKind: PortChangeKindStart}
+	for i := 0; i < subscriberBufferSize+1; i++ {
+		if err := testServer.Notify("serverSharing.sharingSucceeded", notification); err != nil {
+			t.Fatalf("error notifying client: %v", err)
+		}
+	}
+
+	deadline := time.After(2 * time.Second)
+	for {
+		if session.Stats().DroppedEvents > 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("expected at least one dropped event, stats: %+v", session.Stats())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestSessionUnsubscribeClosesChannel(t *testing.T) {
+	testServer, session, err := makeMockSession()
+	if err != nil {
+		t.Fatalf("error creating mock session: %v", err)
+	}
+	defer testServer.Close()
+
+	events, unsubscribe := session.Subscribe(context.Background(), PortEvents)
+	unsubscribe()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected channel to be closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for channel to close")
+	}
+}
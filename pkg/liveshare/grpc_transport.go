@@ -0,0 +1,818 @@
+package liveshare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func init() {
+	encoding.RegisterCodec(protoWireCodec{})
+}
+
+// protoWireCodec marshals the grpc* mirror types below directly to and
+// from the protobuf wire format using protowire, the same low-level
+// encoder protoc-generated code itself calls into. No protoc-generated
+// proto.Message stubs are vendored into this repo, but the bytes this
+// codec produces are indistinguishable on the wire from ones a real
+// protoc-gen-go client would send, so GRPCTransport can talk to any
+// standard gRPC server implementing proto/liveshare.proto, not just
+// pkg/liveshare/test's GRPCServer.
+type protoWireCodec struct{}
+
+func (protoWireCodec) Name() string { return "proto" }
+
+func (protoWireCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(protoWireMessage)
+	if !ok {
+		return nil, fmt.Errorf("liveshare: %T does not implement protoWireMessage", v)
+	}
+	return m.MarshalProtoWire(nil), nil
+}
+
+func (protoWireCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(protoWireMessage)
+	if !ok {
+		return fmt.Errorf("liveshare: %T does not implement protoWireMessage", v)
+	}
+	return m.UnmarshalProtoWire(data)
+}
+
+// protoWireMessage is implemented by every grpc* mirror type, each
+// encoding itself exactly as the corresponding message in
+// proto/liveshare.proto would be encoded by generated code.
+type protoWireMessage interface {
+	MarshalProtoWire(b []byte) []byte
+	UnmarshalProtoWire(b []byte) error
+}
+
+// liveshareService is the full gRPC service name declared in
+// proto/liveshare.proto; RPC method names are resolved relative to it.
+const liveshareService = "/liveshare.Liveshare/"
+
+// grpcEmpty marshals to zero bytes, matching any proto3 message with no
+// fields set (JoinWorkspaceRequest, GetSharedServersRequest, and so on).
+type grpcEmpty struct{}
+
+func (grpcEmpty) MarshalProtoWire(b []byte) []byte   { return b }
+func (*grpcEmpty) UnmarshalProtoWire(b []byte) error { return nil }
+
+// The grpc* types below mirror the request/response messages declared
+// in proto/liveshare.proto, hand-implementing the wire encoding proto3
+// generated code would produce for their field numbers and types.
+type grpcJoinWorkspaceResponse struct {
+	SessionNumber int32
+}
+
+func (m *grpcJoinWorkspaceResponse) MarshalProtoWire(b []byte) []byte {
+	if m.SessionNumber != 0 {
+		b = appendVarintField(b, 1, uint64(m.SessionNumber))
+	}
+	return b
+}
+
+func (m *grpcJoinWorkspaceResponse) UnmarshalProtoWire(b []byte) error {
+	return forEachField(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.SessionNumber = int32(v)
+			return n, nil
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return n, nil
+		}
+	})
+}
+
+type grpcStartSharingRequest struct {
+	Port      int32
+	Protocol  string
+	BrowseURL string
+}
+
+func (m *grpcStartSharingRequest) MarshalProtoWire(b []byte) []byte {
+	if m.Port != 0 {
+		b = appendVarintField(b, 1, uint64(m.Port))
+	}
+	if m.Protocol != "" {
+		b = appendStringField(b, 2, m.Protocol)
+	}
+	if m.BrowseURL != "" {
+		b = appendStringField(b, 3, m.BrowseURL)
+	}
+	return b
+}
+
+func (m *grpcStartSharingRequest) UnmarshalProtoWire(b []byte) error {
+	return forEachField(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Port = int32(v)
+			return n, nil
+		case 2:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Protocol = v
+			return n, nil
+		case 3:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.BrowseURL = v
+			return n, nil
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return n, nil
+		}
+	})
+}
+
+// grpcPort mirrors the Port message, which doubles as StartSharing's
+// response and an element of GetSharedServersResponse.
+type grpcPort struct {
+	SourcePort      int32
+	StreamName      string
+	StreamCondition string
+}
+
+func (m *grpcPort) MarshalProtoWire(b []byte) []byte {
+	if m.SourcePort != 0 {
+		b = appendVarintField(b, 1, uint64(m.SourcePort))
+	}
+	if m.StreamName != "" {
+		b = appendStringField(b, 2, m.StreamName)
+	}
+	if m.StreamCondition != "" {
+		b = appendStringField(b, 3, m.StreamCondition)
+	}
+	return b
+}
+
+func (m *grpcPort) UnmarshalProtoWire(b []byte) error {
+	return forEachField(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.SourcePort = int32(v)
+			return n, nil
+		case 2:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.StreamName = v
+			return n, nil
+		case 3:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.StreamCondition = v
+			return n, nil
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return n, nil
+		}
+	})
+}
+
+func (m *grpcPort) toPort() *Port {
+	return &Port{SourcePort: int(m.SourcePort), StreamName: m.StreamName, StreamCondition: m.StreamCondition}
+}
+
+func portToGRPC(p *Port) *grpcPort {
+	return &grpcPort{SourcePort: int32(p.SourcePort), StreamName: p.StreamName, StreamCondition: p.StreamCondition}
+}
+
+type grpcGetSharedServersResponse struct {
+	Ports []*grpcPort
+}
+
+func (m *grpcGetSharedServersResponse) MarshalProtoWire(b []byte) []byte {
+	for _, p := range m.Ports {
+		b = appendMessageField(b, 1, p)
+	}
+	return b
+}
+
+func (m *grpcGetSharedServersResponse) UnmarshalProtoWire(b []byte) error {
+	return forEachField(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			raw, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			p := &grpcPort{}
+			if err := p.UnmarshalProtoWire(raw); err != nil {
+				return 0, err
+			}
+			m.Ports = append(m.Ports, p)
+			return n, nil
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return n, nil
+		}
+	})
+}
+
+type grpcUpdateSharedServerPrivacyRequest struct {
+	Port       int32
+	Visibility string
+}
+
+func (m *grpcUpdateSharedServerPrivacyRequest) MarshalProtoWire(b []byte) []byte {
+	if m.Port != 0 {
+		b = appendVarintField(b, 1, uint64(m.Port))
+	}
+	if m.Visibility != "" {
+		b = appendStringField(b, 2, m.Visibility)
+	}
+	return b
+}
+
+func (m *grpcUpdateSharedServerPrivacyRequest) UnmarshalProtoWire(b []byte) error {
+	return forEachField(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Port = int32(v)
+			return n, nil
+		case 2:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Visibility = v
+			return n, nil
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return n, nil
+		}
+	})
+}
+
+type grpcNotifyActivityRequest struct {
+	ClientName string
+	Activities []string
+}
+
+func (m *grpcNotifyActivityRequest) MarshalProtoWire(b []byte) []byte {
+	if m.ClientName != "" {
+		b = appendStringField(b, 1, m.ClientName)
+	}
+	for _, a := range m.Activities {
+		b = appendStringField(b, 2, a)
+	}
+	return b
+}
+
+func (m *grpcNotifyActivityRequest) UnmarshalProtoWire(b []byte) error {
+	return forEachField(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.ClientName = v
+			return n, nil
+		case 2:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Activities = append(m.Activities, v)
+			return n, nil
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return n, nil
+		}
+	})
+}
+
+type grpcRebuildContainerRequest struct {
+	FullRebuild bool
+}
+
+func (m *grpcRebuildContainerRequest) MarshalProtoWire(b []byte) []byte {
+	if m.FullRebuild {
+		b = appendVarintField(b, 1, protowire.EncodeBool(m.FullRebuild))
+	}
+	return b
+}
+
+func (m *grpcRebuildContainerRequest) UnmarshalProtoWire(b []byte) error {
+	return forEachField(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.FullRebuild = protowire.DecodeBool(v)
+			return n, nil
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return n, nil
+		}
+	})
+}
+
+type grpcRebuildContainerResponse struct {
+	Accepted bool
+}
+
+func (m *grpcRebuildContainerResponse) MarshalProtoWire(b []byte) []byte {
+	if m.Accepted {
+		b = appendVarintField(b, 1, protowire.EncodeBool(m.Accepted))
+	}
+	return b
+}
+
+func (m *grpcRebuildContainerResponse) UnmarshalProtoWire(b []byte) error {
+	return forEachField(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Accepted = protowire.DecodeBool(v)
+			return n, nil
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return n, nil
+		}
+	})
+}
+
+type grpcPortNotification struct {
+	Port       int32
+	ChangeKind string
+}
+
+func (m *grpcPortNotification) MarshalProtoWire(b []byte) []byte {
+	if m.Port != 0 {
+		b = appendVarintField(b, 1, uint64(m.Port))
+	}
+	if m.ChangeKind != "" {
+		b = appendStringField(b, 2, m.ChangeKind)
+	}
+	return b
+}
+
+func (m *grpcPortNotification) UnmarshalProtoWire(b []byte) error {
+	return forEachField(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Port = int32(v)
+			return n, nil
+		case 2:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.ChangeKind = v
+			return n, nil
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return n, nil
+		}
+	})
+}
+
+type grpcHostNotification struct {
+	ChangeKind string
+}
+
+func (m *grpcHostNotification) MarshalProtoWire(b []byte) []byte {
+	if m.ChangeKind != "" {
+		b = appendStringField(b, 1, m.ChangeKind)
+	}
+	return b
+}
+
+func (m *grpcHostNotification) UnmarshalProtoWire(b []byte) error {
+	return forEachField(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.ChangeKind = v
+			return n, nil
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return n, nil
+		}
+	})
+}
+
+type grpcRebuildNotification struct {
+	Message  string
+	Progress int32
+}
+
+func (m *grpcRebuildNotification) MarshalProtoWire(b []byte) []byte {
+	if m.Message != "" {
+		b = appendStringField(b, 1, m.Message)
+	}
+	if m.Progress != 0 {
+		b = appendVarintField(b, 2, uint64(m.Progress))
+	}
+	return b
+}
+
+func (m *grpcRebuildNotification) UnmarshalProtoWire(b []byte) error {
+	return forEachField(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Message = v
+			return n, nil
+		case 2:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Progress = int32(v)
+			return n, nil
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return n, nil
+		}
+	})
+}
+
+// grpcEventNotification mirrors the EventNotification oneof: exactly
+// one field is set per message received from the Events stream.
+type grpcEventNotification struct {
+	Port    *grpcPortNotification
+	Host    *grpcHostNotification
+	Rebuild *grpcRebuildNotification
+}
+
+func (m *grpcEventNotification) MarshalProtoWire(b []byte) []byte {
+	switch {
+	case m.Port != nil:
+		b = appendMessageField(b, 1, m.Port)
+	case m.Host != nil:
+		b = appendMessageField(b, 2, m.Host)
+	case m.Rebuild != nil:
+		b = appendMessageField(b, 3, m.Rebuild)
+	}
+	return b
+}
+
+func (m *grpcEventNotification) UnmarshalProtoWire(b []byte) error {
+	return forEachField(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			raw, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Port = &grpcPortNotification{}
+			if err := m.Port.UnmarshalProtoWire(raw); err != nil {
+				return 0, err
+			}
+			return n, nil
+		case 2:
+			raw, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Host = &grpcHostNotification{}
+			if err := m.Host.UnmarshalProtoWire(raw); err != nil {
+				return 0, err
+			}
+			return n, nil
+		case 3:
+			raw, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Rebuild = &grpcRebuildNotification{}
+			if err := m.Rebuild.UnmarshalProtoWire(raw); err != nil {
+				return 0, err
+			}
+			return n, nil
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return n, nil
+		}
+	})
+}
+
+func appendVarintField(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendStringField(b []byte, num protowire.Number, v string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendMessageField(b []byte, num protowire.Number, m protoWireMessage) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m.MarshalProtoWire(nil))
+}
+
+// forEachField walks every top-level field in an encoded message,
+// calling consume for each one; consume returns the number of bytes it
+// read from the field's value (via protowire.Consume*) or, for an
+// unrecognized field number, delegates to protowire.ConsumeFieldValue
+// to skip it.
+func forEachField(b []byte, consume func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		m, err := consume(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if m < 0 {
+			return protowire.ParseError(m)
+		}
+		b = b[m:]
+	}
+	return nil
+}
+
+// GRPCTransport is an RPCTransport backed by a gRPC connection, for
+// targeting self-hosted codespace backends that don't speak the Azure
+// Relay protocol the default relayTransport uses. It calls the RPC
+// surface declared in proto/liveshare.proto directly, encoding and
+// decoding real protobuf wire bytes via protoWireCodec rather than
+// protoc-generated stubs, so it interoperates with a standard gRPC
+// server implementing that service.
+type GRPCTransport struct {
+	conn *grpc.ClientConn
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+
+	disconnectc chan struct{}
+	closeOnce   sync.Once
+}
+
+// NewGRPCTransport dials target and returns a transport suitable for
+// Options.Transport. It opens the Events stream immediately; an event
+// that arrives before a handler for its method is registered via Handle
+// is dropped.
+func NewGRPCTransport(ctx context.Context, target string, opts ...grpc.DialOption) (*GRPCTransport, error) {
+	conn, err := grpc.DialContext(ctx, target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error dialing grpc target: %w", err)
+	}
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, liveshareService+"Events")
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error opening events stream: %w", err)
+	}
+	if err := stream.SendMsg(&grpcEmpty{}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error starting events stream: %w", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error starting events stream: %w", err)
+	}
+
+	t := &GRPCTransport{
+		conn:        conn,
+		handlers:    make(map[string]Handler),
+		disconnectc: make(chan struct{}),
+	}
+	go t.recvEvents(ctx, stream)
+
+	return t, nil
+}
+
+func (t *GRPCTransport) recvEvents(ctx context.Context, stream grpc.ClientStream) {
+	defer t.disconnect()
+	for {
+		evt := &grpcEventNotification{}
+		if err := stream.RecvMsg(evt); err != nil {
+			return
+		}
+		switch {
+		case evt.Port != nil:
+			t.dispatch(ctx, "serverSharing.sharingStarted", &PortNotification{Port: int(evt.Port.Port), ChangeKind: PortChangeKind(evt.Port.ChangeKind)})
+		case evt.Host != nil:
+			t.dispatch(ctx, "serverSharing.serverChanged", &HostNotification{ChangeKind: evt.Host.ChangeKind})
+		case evt.Rebuild != nil:
+			t.dispatch(ctx, "IEnvironmentConfigurationService.progressUpdate", &RebuildNotification{Message: evt.Rebuild.Message, Progress: int(evt.Rebuild.Progress)})
+		}
+	}
+}
+
+func (t *GRPCTransport) dispatch(ctx context.Context, method string, payload interface{}) {
+	t.mu.Lock()
+	h, ok := t.handlers[method]
+	t.mu.Unlock()
+	if !ok {
+		return
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	rawMsg := json.RawMessage(raw)
+	h(ctx, method, &rawMsg)
+}
+
+func (t *GRPCTransport) disconnect() {
+	t.closeOnce.Do(func() { close(t.disconnectc) })
+}
+
+// positionalArgs type-asserts params as the []interface{} the Session
+// methods build their call arguments from.
+func positionalArgs(method string, params interface{}, n int) ([]interface{}, error) {
+	args, ok := params.([]interface{})
+	if !ok || len(args) != n {
+		return nil, fmt.Errorf("liveshare: expected %d positional params for %s, got %#v", n, method, params)
+	}
+	return args, nil
+}
+
+func (t *GRPCTransport) invoke(ctx context.Context, rpc string, req, reply protoWireMessage) error {
+	if err := t.conn.Invoke(ctx, liveshareService+rpc, req, reply); err != nil {
+		return fmt.Errorf("error invoking %s: %w", rpc, err)
+	}
+	return nil
+}
+
+// Call maps a Session RPC call onto the corresponding proto/liveshare.proto
+// method, since the jsonrpc2-style (method, positional params) shape
+// Session.getTransport().Call uses has no gRPC equivalent to decode
+// generically.
+func (t *GRPCTransport) Call(ctx context.Context, method string, params, result interface{}) error {
+	switch method {
+	case "workspace.joinWorkspace":
+		resp := &grpcJoinWorkspaceResponse{}
+		if err := t.invoke(ctx, "JoinWorkspace", &grpcEmpty{}, resp); err != nil {
+			return err
+		}
+		if out, ok := result.(*joinWorkspaceResult); ok {
+			out.SessionNumber = int(resp.SessionNumber)
+		}
+		return nil
+	case "serverSharing.startSharing":
+		args, err := positionalArgs(method, params, 3)
+		if err != nil {
+			return err
+		}
+		port, _ := args[0].(int)
+		protocol, _ := args[1].(string)
+		browseURL, _ := args[2].(string)
+		req := &grpcStartSharingRequest{Port: int32(port), Protocol: protocol, BrowseURL: browseURL}
+		resp := &grpcPort{}
+		if err := t.invoke(ctx, "StartSharing", req, resp); err != nil {
+			return err
+		}
+		if out, ok := result.(*Port); ok {
+			*out = *resp.toPort()
+		}
+		return nil
+	case "serverSharing.getSharedServers":
+		resp := &grpcGetSharedServersResponse{}
+		if err := t.invoke(ctx, "GetSharedServers", &grpcEmpty{}, resp); err != nil {
+			return err
+		}
+		if out, ok := result.(*[]*Port); ok {
+			ports := make([]*Port, len(resp.Ports))
+			for i, p := range resp.Ports {
+				ports[i] = p.toPort()
+			}
+			*out = ports
+		}
+		return nil
+	case "serverSharing.updateSharedServerPrivacy":
+		args, err := positionalArgs(method, params, 2)
+		if err != nil {
+			return err
+		}
+		port, _ := args[0].(int)
+		visibility, _ := args[1].(string)
+		req := &grpcUpdateSharedServerPrivacyRequest{Port: int32(port), Visibility: visibility}
+		return t.invoke(ctx, "UpdateSharedServerPrivacy", req, &grpcEmpty{})
+	case "ICodespaceHostService.notifyCodespaceOfClientActivity":
+		args, err := positionalArgs(method, params, 2)
+		if err != nil {
+			return err
+		}
+		clientName, _ := args[0].(string)
+		activities, _ := args[1].([]string)
+		req := &grpcNotifyActivityRequest{ClientName: clientName, Activities: activities}
+		return t.invoke(ctx, "NotifyCodespaceOfClientActivity", req, &grpcEmpty{})
+	case "IEnvironmentConfigurationService.incrementalRebuildContainer", "IEnvironmentConfigurationService.rebuildContainer":
+		req := &grpcRebuildContainerRequest{FullRebuild: method == "IEnvironmentConfigurationService.rebuildContainer"}
+		resp := &grpcRebuildContainerResponse{}
+		if err := t.invoke(ctx, "RebuildContainer", req, resp); err != nil {
+			return err
+		}
+		if out, ok := result.(*bool); ok {
+			*out = resp.Accepted
+		}
+		return nil
+	default:
+		return fmt.Errorf("liveshare: grpc transport has no mapping for method %q", method)
+	}
+}
+
+// Notify issues method the same way Call does, discarding the
+// response. proto/liveshare.proto has no fire-and-forget RPC shape, so
+// this is simply a Call whose result the caller doesn't read.
+func (t *GRPCTransport) Notify(ctx context.Context, method string, params interface{}) error {
+	return t.Call(ctx, method, params, nil)
+}
+
+func (t *GRPCTransport) Handle(method string, h Handler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handlers[method] = h
+}
+
+// DisconnectNotify returns a channel closed once the Events stream
+// ends, whether from a lost connection or the transport being closed.
+func (t *GRPCTransport) DisconnectNotify() <-chan struct{} {
+	return t.disconnectc
+}
+
+func (t *GRPCTransport) Close() error {
+	t.disconnect()
+	return t.conn.Close()
+}
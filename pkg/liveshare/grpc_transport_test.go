@@ -0,0 +1,46 @@
+package liveshare
+
+import (
+	"context"
+	"fmt"
+
+	livesharetest "github.com/cli/cli/v2/pkg/liveshare/test"
+)
+
+// grpcJoinWorkspace is the JoinWorkspace response every dual-transport
+// test's grpc invoke func returns, since every Session.Connect joins
+// the workspace before anything else can happen.
+func grpcJoinWorkspace(method string) ([]byte, bool) {
+	if method != "/liveshare.Liveshare/JoinWorkspace" {
+		return nil, false
+	}
+	return (&grpcJoinWorkspaceResponse{SessionNumber: 1}).MarshalProtoWire(nil), true
+}
+
+// makeMockGRPCSession is the GRPCTransport counterpart to
+// makeMockSession: it stands up an in-process gRPC server and connects
+// a Session to it, so the same session-level behavior can be exercised
+// against either transport. events, if non-nil, is forwarded to the
+// session's Events stream as raw protobuf-encoded grpcEventNotification
+// messages.
+func makeMockGRPCSession(invoke livesharetest.GRPCInvoke, events <-chan []byte) (*livesharetest.GRPCServer, *Session, error) {
+	grpcServer := livesharetest.NewGRPCServer(invoke, events)
+
+	ctx := context.Background()
+	transport, err := NewGRPCTransport(ctx, "bufnet", grpcServer.DialOptions()...)
+	if err != nil {
+		grpcServer.Close()
+		return nil, nil, fmt.Errorf("error creating grpc transport: %w", err)
+	}
+
+	session, err := Connect(ctx, Options{
+		ClientName: mockClientName,
+		Transport:  transport,
+		Logger:     newMockLogger(),
+	})
+	if err != nil {
+		grpcServer.Close()
+		return nil, nil, fmt.Errorf("error connecting to Live Share: %w", err)
+	}
+	return grpcServer, session, nil
+}
@@ -0,0 +1,28 @@
+package liveshare
+
+import "crypto/tls"
+
+// logger is the subset of *log.Logger used by a Session. It lets callers
+// supply their own logger without requiring them to import "log".
+type logger interface {
+	Println(v ...interface{})
+	Printf(format string, v ...interface{})
+}
+
+// Options specifies the parameters required to connect to a Live Share
+// workspace hosted by a codespace.
+type Options struct {
+	ClientName     string
+	SessionID      string
+	SessionToken   string
+	RelaySAS       string
+	RelayEndpoint  string
+	HostPublicKeys []string
+	TLSConfig      *tls.Config
+	Logger         logger
+
+	// Transport overrides how the Session talks to the host. The zero
+	// value selects the default: jsonrpc2 over an SSH tunnel through
+	// the Azure Relay described by RelayEndpoint and RelaySAS.
+	Transport RPCTransport
+}
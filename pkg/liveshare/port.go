@@ -0,0 +1,22 @@
+package liveshare
+
+// PortChangeKind describes how a shared port's state changed.
+type PortChangeKind string
+
+const (
+	PortChangeKindStart PortChangeKind = "start"
+	PortChangeKindEnd   PortChangeKind = "end"
+)
+
+// Port describes a server port shared from within a codespace.
+type Port struct {
+	SourcePort      int    `json:"sourcePort"`
+	StreamName      string `json:"streamName"`
+	StreamCondition string `json:"streamCondition"`
+}
+
+// PortNotification is sent by the host whenever a shared port changes state.
+type PortNotification struct {
+	Port       int            `json:"port"`
+	ChangeKind PortChangeKind `json:"changeKind"`
+}
@@ -0,0 +1,146 @@
+package liveshare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// maxReconnectAttempts bounds how many times watchDisconnect redials
+// the transport before giving up and failing the session.
+const maxReconnectAttempts = 5
+
+// reconnectBackoffFloor and reconnectBackoffCeiling bound the delay
+// between redial attempts.
+const (
+	reconnectBackoffFloor   = 500 * time.Millisecond
+	reconnectBackoffCeiling = 30 * time.Second
+)
+
+// ReconnectState describes a transition reported through the
+// subscription API while the session is recovering a dropped connection.
+type ReconnectState string
+
+const (
+	ReconnectStateReconnecting ReconnectState = "reconnecting"
+	ReconnectStateReconnected  ReconnectState = "reconnected"
+)
+
+// ReconnectNotification is published whenever the session starts or
+// finishes reconnecting its transport.
+type ReconnectNotification struct {
+	State ReconnectState
+}
+
+// Stats reports cumulative counters useful for diagnosing a Session's
+// background workers.
+type Stats struct {
+	Heartbeats        int
+	ReconnectAttempts int
+	Reconnects        int
+	DroppedEvents     int
+}
+
+// Stats returns a snapshot of the session's background worker counters.
+func (s *Session) Stats() Stats {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	return s.stats
+}
+
+func (s *Session) recordHeartbeat() {
+	s.statsMu.Lock()
+	s.stats.Heartbeats++
+	s.statsMu.Unlock()
+}
+
+func (s *Session) recordReconnectAttempt() {
+	s.statsMu.Lock()
+	s.stats.ReconnectAttempts++
+	s.statsMu.Unlock()
+}
+
+func (s *Session) recordReconnect() {
+	s.statsMu.Lock()
+	s.stats.Reconnects++
+	s.statsMu.Unlock()
+}
+
+func (s *Session) recordDroppedEvent() {
+	s.statsMu.Lock()
+	s.stats.DroppedEvents++
+	s.statsMu.Unlock()
+}
+
+// watchDisconnect waits for the transport to report a lost connection
+// and reconnects it, repeating for as long as ctx is not done.
+func (s *Session) watchDisconnect(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.getTransport().DisconnectNotify():
+			if err := s.reconnectWithBackoff(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Session) reconnectWithBackoff(ctx context.Context) error {
+	if s.reconnect == nil {
+		return errors.New("liveshare: session transport disconnected and has no reconnect strategy")
+	}
+
+	s.publish(Event{Type: ReconnectEvents, Reconnect: &ReconnectNotification{State: ReconnectStateReconnecting}})
+
+	window := reconnectBackoffFloor
+	var lastErr error
+	for attempt := 1; attempt <= maxReconnectAttempts; attempt++ {
+		s.recordReconnectAttempt()
+
+		transport, err := s.reconnect(ctx)
+		if err == nil {
+			old := s.getTransport()
+			s.setTransport(transport)
+			s.registerEventHandlers(transport)
+			// old is already disconnected; closing it is just releasing
+			// its resources, so a failure here isn't worth surfacing.
+			_ = old.Close()
+			s.recordReconnect()
+			s.publish(Event{Type: ReconnectEvents, Reconnect: &ReconnectNotification{State: ReconnectStateReconnected}})
+			return nil
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jitter(window)):
+		}
+		window = backoff(window, reconnectBackoffCeiling)
+	}
+
+	return fmt.Errorf("error reconnecting after %d attempts: %w", maxReconnectAttempts, lastErr)
+}
+
+// backoff doubles d, capped at ceiling.
+func backoff(d, ceiling time.Duration) time.Duration {
+	d *= 2
+	if d > ceiling {
+		d = ceiling
+	}
+	return d
+}
+
+// jitter returns d plus or minus up to 25%, so that a fleet of sessions
+// recovering from the same outage doesn't retry in lockstep.
+func jitter(d time.Duration) time.Duration {
+	spread := int64(d) / 4
+	if spread <= 0 {
+		return d
+	}
+	return d - time.Duration(spread) + time.Duration(rand.Int63n(2*spread+1))
+}
@@ -0,0 +1,79 @@
+package liveshare
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// relayTransport is the default RPCTransport: jsonrpc2 carried over an
+// SSH channel tunneled through the Azure Relay endpoint handed out by
+// the Codespaces API.
+type relayTransport struct {
+	ssh  *sshSession
+	conn *jsonrpc2.Conn
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+}
+
+// newRelayTransport dials the relay described by opts and establishes
+// the SSH tunnel and jsonrpc2 connection used for the session's RPC.
+func newRelayTransport(ctx context.Context, opts Options) (*relayTransport, error) {
+	ssh, err := newSSHSession(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error creating ssh session: %w", err)
+	}
+
+	t := &relayTransport{ssh: ssh, handlers: make(map[string]Handler)}
+	stream := jsonrpc2.NewBufferedStream(ssh.channel, jsonrpc2.VSCodeObjectCodec{})
+	t.conn = jsonrpc2.NewConn(ctx, stream, jsonrpc2.HandlerWithError(t.dispatch))
+	return t, nil
+}
+
+func (t *relayTransport) dispatch(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+	t.mu.Lock()
+	h, ok := t.handlers[req.Method]
+	t.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	return h(ctx, req.Method, req.Params)
+}
+
+func (t *relayTransport) Call(ctx context.Context, method string, params, result interface{}) error {
+	return t.conn.Call(ctx, method, params, result)
+}
+
+func (t *relayTransport) Notify(ctx context.Context, method string, params interface{}) error {
+	return t.conn.Notify(ctx, method, params)
+}
+
+func (t *relayTransport) Handle(method string, h Handler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handlers[method] = h
+}
+
+func (t *relayTransport) DisconnectNotify() <-chan struct{} {
+	return t.conn.DisconnectNotify()
+}
+
+func (t *relayTransport) Close() error {
+	// Close both layers unconditionally rather than stopping at the
+	// first error: Close is also called on a transport the reconnect
+	// loop is superseding after DisconnectNotify already fired, in
+	// which case t.conn is already closed (jsonrpc2.ErrClosed) and
+	// skipping t.ssh.Close() would leak the underlying SSH client.
+	connErr := t.conn.Close()
+	if connErr == jsonrpc2.ErrClosed {
+		connErr = nil
+	}
+	sshErr := t.ssh.Close()
+	if connErr != nil {
+		return connErr
+	}
+	return sshErr
+}
@@ -0,0 +1,335 @@
+package liveshare
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// heartbeatInterval is the minimum heartbeat window Start passes to the
+// session's heartbeat loop; while idle, the loop backs off beyond it.
+const heartbeatInterval = 2 * time.Second
+
+type joinWorkspaceResult struct {
+	SessionNumber int
+}
+
+// Session is a connection to a Live Share workspace hosted by a
+// codespace. Connect returns a Session that has joined the workspace
+// but has not yet started any background workers; call Start once
+// handlers of interest have been registered.
+type Session struct {
+	transport RPCTransport
+
+	clientName string
+	logger     logger
+
+	keepAliveReason chan string
+
+	// reconnect redials the transport from scratch and re-joins the
+	// workspace. It is nil when Options.Transport was supplied by the
+	// caller, since there's no relay/SSH configuration to redial.
+	reconnect func(ctx context.Context) (RPCTransport, error)
+
+	mu      sync.Mutex
+	started bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+	err     error
+
+	subsMu sync.Mutex
+	subs   map[*subscriber]struct{}
+
+	statsMu sync.Mutex
+	stats   Stats
+}
+
+// Connect establishes the session's transport (by default, an SSH
+// tunnel to the Live Share relay described by opts) and joins the
+// workspace. The returned Session has not been started; callers must
+// call Start before relying on background behavior such as the
+// heartbeat.
+func Connect(ctx context.Context, opts Options) (*Session, error) {
+	transport := opts.Transport
+	if transport == nil {
+		relay, err := newRelayTransport(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error creating relay transport: %w", err)
+		}
+		transport = relay
+	}
+
+	session := &Session{
+		transport:       transport,
+		clientName:      opts.ClientName,
+		logger:          opts.Logger,
+		keepAliveReason: make(chan string, 1),
+		subs:            make(map[*subscriber]struct{}),
+	}
+
+	if opts.Transport == nil {
+		session.reconnect = func(ctx context.Context) (RPCTransport, error) {
+			relay, err := newRelayTransport(ctx, opts)
+			if err != nil {
+				return nil, fmt.Errorf("error creating relay transport: %w", err)
+			}
+			if err := joinWorkspace(ctx, relay); err != nil {
+				relay.Close()
+				return nil, err
+			}
+			return relay, nil
+		}
+	}
+
+	session.registerEventHandlers(transport)
+
+	if err := joinWorkspace(ctx, transport); err != nil {
+		return nil, fmt.Errorf("error joining workspace: %w", err)
+	}
+
+	return session, nil
+}
+
+func joinWorkspace(ctx context.Context, transport RPCTransport) error {
+	var result joinWorkspaceResult
+	return transport.Call(ctx, "workspace.joinWorkspace", []interface{}{}, &result)
+}
+
+func (s *Session) registerEventHandlers(transport RPCTransport) {
+	for _, method := range eventMethods {
+		transport.Handle(method, s.handleNotification)
+	}
+}
+
+func (s *Session) handleNotification(ctx context.Context, method string, params *json.RawMessage) (interface{}, error) {
+	evt, ok, err := decodeEvent(method, params)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		s.publish(evt)
+	}
+	return nil, nil
+}
+
+// Start spins up the session's background workers, the heartbeat and
+// the connection watcher that reconnects it after a relay drop, under a
+// single supervised errgroup. It must be called at most once per
+// Session.
+func (s *Session) Start(ctx context.Context) error {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return errors.New("session already started")
+	}
+	s.started = true
+	s.done = make(chan struct{})
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		return s.heartbeat(groupCtx, heartbeatInterval)
+	})
+	group.Go(func() error {
+		return s.watchDisconnect(groupCtx)
+	})
+
+	go func() {
+		defer close(s.done)
+		if err := group.Wait(); err != nil && !errors.Is(err, context.Canceled) {
+			s.setErr(err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop cancels every worker started by Start and closes the underlying
+// transport. It does not wait for workers to exit; call Wait for that.
+func (s *Session) Stop() error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	var closeErr error
+	if transport := s.getTransport(); transport != nil {
+		closeErr = transport.Close()
+	}
+	// A worker may have already failed and recorded the reason a
+	// transport close would fail for (the transport is already dead),
+	// so prefer that more informative error if one was recorded.
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return fmt.Errorf("error closing transport: %w", closeErr)
+	}
+	return nil
+}
+
+func (s *Session) getTransport() RPCTransport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transport
+}
+
+func (s *Session) setTransport(transport RPCTransport) {
+	s.mu.Lock()
+	s.transport = transport
+	s.mu.Unlock()
+}
+
+// Wait blocks until every worker started by Start has exited and
+// returns the first terminal error encountered, if any.
+func (s *Session) Wait() error {
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+	return s.Err()
+}
+
+// Err returns the first terminal error reported by a background worker
+// started by Start, or nil if none has occurred.
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Session) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// KeepAlive notifies the host that the client is still active for the
+// given reason. It never blocks: if the notification cannot be queued
+// immediately, it is dropped and never reported. Reasons that are
+// queued are coalesced into the next heartbeat's single notification.
+func (s *Session) KeepAlive(reason string) {
+	select {
+	case s.keepAliveReason <- reason:
+	default:
+	}
+}
+
+func (s *Session) notifyHostOfActivity(ctx context.Context, activities ...string) error {
+	params := []interface{}{s.clientName, activities}
+	return s.getTransport().Call(ctx, "ICodespaceHostService.notifyCodespaceOfClientActivity", params, nil)
+}
+
+// heartbeatBackoffCeiling bounds how far the heartbeat's idle interval
+// is allowed to grow.
+const heartbeatBackoffCeiling = 10 * time.Second
+
+// heartbeat notifies the host of accumulated KeepAlive reasons, coalescing
+// reasons that arrive within the same window into one call. While idle it
+// backs off the window exponentially (with jitter) up to
+// heartbeatBackoffCeiling; a new reason resets the window back to minInterval
+// immediately.
+func (s *Session) heartbeat(ctx context.Context, minInterval time.Duration) error {
+	window := minInterval
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+
+	seen := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case reason := <-s.keepAliveReason:
+			seen[reason] = true
+			if !timer.Stop() {
+				<-timer.C
+			}
+			window = minInterval
+			timer.Reset(window)
+		case <-timer.C:
+			if len(seen) == 0 {
+				window = backoff(window, heartbeatBackoffCeiling)
+				timer.Reset(jitter(window))
+				continue
+			}
+			reasons := make([]string, 0, len(seen))
+			for reason := range seen {
+				reasons = append(reasons, reason)
+			}
+			sort.Strings(reasons)
+			if s.logger != nil {
+				s.logger.Println(reasons)
+			}
+			if err := s.notifyHostOfActivity(ctx, reasons...); err != nil {
+				return fmt.Errorf("error notifying host of activity: %w", err)
+			}
+			s.recordHeartbeat()
+			seen = make(map[string]bool)
+			window = minInterval
+			timer.Reset(window)
+		}
+	}
+}
+
+// streamID identifies a stream returned by serverSharing.startSharing.
+type streamID struct {
+	name      string
+	condition string
+}
+
+// StartSharing shares the given local port and protocol from the
+// container, returning the identifier of the resulting stream.
+func (s *Session) StartSharing(ctx context.Context, protocol string, port int) (streamID, error) {
+	args := []interface{}{port, protocol, fmt.Sprintf("http://localhost:%d", port)}
+	var response Port
+	if err := s.getTransport().Call(ctx, "serverSharing.startSharing", args, &response); err != nil {
+		return streamID{}, fmt.Errorf("error sharing port %d: %w", port, err)
+	}
+	return streamID{name: response.StreamName, condition: response.StreamCondition}, nil
+}
+
+// GetSharedServers lists the ports currently shared from the container.
+func (s *Session) GetSharedServers(ctx context.Context) ([]*Port, error) {
+	var response []*Port
+	if err := s.getTransport().Call(ctx, "serverSharing.getSharedServers", []interface{}{}, &response); err != nil {
+		return nil, fmt.Errorf("error getting shared servers: %w", err)
+	}
+	return response, nil
+}
+
+// UpdateSharedServerPrivacy changes the visibility of a previously
+// shared port ("private", "org", or "public").
+func (s *Session) UpdateSharedServerPrivacy(ctx context.Context, port int, visibility string) error {
+	args := []interface{}{port, visibility}
+	if err := s.getTransport().Call(ctx, "serverSharing.updateSharedServerPrivacy", args, nil); err != nil {
+		return fmt.Errorf("error updating port visibility: %w", err)
+	}
+	return nil
+}
+
+// RebuildContainer asks the host to rebuild the dev container, either
+// incrementally or from scratch when full is true.
+func (s *Session) RebuildContainer(ctx context.Context, full bool) error {
+	method := "IEnvironmentConfigurationService.incrementalRebuildContainer"
+	if full {
+		method = "IEnvironmentConfigurationService.rebuildContainer"
+	}
+	var result bool
+	if err := s.getTransport().Call(ctx, method, []interface{}{}, &result); err != nil {
+		return fmt.Errorf("error rebuilding container: %w", err)
+	}
+	return nil
+}
@@ -49,77 +49,146 @@ func makeMockSession(opts ...livesharetest.ServerOption) (*livesharetest.Server,
 	return testServer, session, nil
 }
 
+// sessionTransportCase builds a Session wired to a mock backend -
+// either the relay/SSH test server or the gRPC test server - along
+// with that backend's asynchronous error channel (nil for gRPC, which
+// has no separate out-of-band error channel) and a cleanup func. Tests
+// that exercise a single RPC against both transports build a table of
+// these.
+type sessionTransportCase struct {
+	name       string
+	newSession func(t *testing.T) (session *Session, errc <-chan error, cleanup func())
+}
+
 func TestServerStartSharing(t *testing.T) {
-	serverPort, serverProtocol := 2222, "sshd"
-	sendNotification := make(chan portUpdateNotification)
-	startSharing := func(conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
-		var args []interface{}
-		if err := json.Unmarshal(*req.Params, &args); err != nil {
-			return nil, fmt.Errorf("error unmarshaling request: %w", err)
-		}
-		if len(args) < 3 {
-			return nil, errors.New("not enough arguments to start sharing")
-		}
-		port, ok := args[0].(float64)
-		if !ok {
-			return nil, errors.New("port argument is not an int")
-		}
-		if port != float64(serverPort) {
-			return nil, errors.New("port does not match serverPort")
-		}
-		if protocol, ok := args[1].(string); !ok {
-			return nil, errors.New("protocol argument is not a string")
-		} else if protocol != serverProtocol {
-			return nil, errors.New("protocol does not match serverProtocol")
-		}
-		if browseURL, ok := args[2].(string); !ok {
-			return nil, errors.New("browse url is not a string")
-		} else if browseURL != fmt.Sprintf("http://localhost:%d", serverPort) {
-			return nil, errors.New("browseURL does not match expected")
-		}
-		sendNotification <- portUpdateNotification{
-			PortNotification: PortNotification{
-				Port:       int(port),
-				ChangeKind: PortChangeKindStart,
-			},
-			conn: conn,
-		}
-		return Port{StreamName: "stream-name", StreamCondition: "stream-condition"}, nil
-	}
-	testServer, session, err := makeMockSession(
-		livesharetest.WithService("serverSharing.startSharing", startSharing),
-	)
-	defer testServer.Close() //nolint:staticcheck // httptest.Server does not return errors on Close()
+	const serverPort, serverProtocol = 2222, "sshd"
 
-	if err != nil {
-		t.Errorf("error creating mock session: %v", err)
+	tests := []sessionTransportCase{
+		{
+			name: "relay",
+			newSession: func(t *testing.T) (*Session, <-chan error, func()) {
+				startSharing := func(conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+					var args []interface{}
+					if err := json.Unmarshal(*req.Params, &args); err != nil {
+						return nil, fmt.Errorf("error unmarshaling request: %w", err)
+					}
+					if len(args) < 3 {
+						return nil, errors.New("not enough arguments to start sharing")
+					}
+					port, ok := args[0].(float64)
+					if !ok {
+						return nil, errors.New("port argument is not an int")
+					}
+					if port != float64(serverPort) {
+						return nil, errors.New("port does not match serverPort")
+					}
+					if protocol, ok := args[1].(string); !ok {
+						return nil, errors.New("protocol argument is not a string")
+					} else if protocol != serverProtocol {
+						return nil, errors.New("protocol does not match serverProtocol")
+					}
+					if browseURL, ok := args[2].(string); !ok {
+						return nil, errors.New("browse url is not a string")
+					} else if browseURL != fmt.Sprintf("http://localhost:%d", serverPort) {
+						return nil, errors.New("browseURL does not match expected")
+					}
+					if err := conn.Notify(context.Background(), "serverSharing.sharingSucceeded", PortNotification{
+						Port:       int(port),
+						ChangeKind: PortChangeKindStart,
+					}); err != nil {
+						return nil, fmt.Errorf("error notifying client of sharingSucceeded: %w", err)
+					}
+					return Port{StreamName: "stream-name", StreamCondition: "stream-condition"}, nil
+				}
+				testServer, session, err := makeMockSession(
+					livesharetest.WithService("serverSharing.startSharing", startSharing),
+				)
+				if err != nil {
+					t.Fatalf("error creating mock session: %v", err)
+				}
+				return session, testServer.Err(), func() { testServer.Close() } //nolint:staticcheck // httptest.Server does not return errors on Close()
+			},
+		},
+		{
+			name: "grpc",
+			newSession: func(t *testing.T) (*Session, <-chan error, func()) {
+				events := make(chan []byte, 1)
+				invoke := func(ctx context.Context, method string, body []byte) ([]byte, error) {
+					if resp, ok := grpcJoinWorkspace(method); ok {
+						return resp, nil
+					}
+					if method != "/liveshare.Liveshare/StartSharing" {
+						return nil, fmt.Errorf("unexpected method %q", method)
+					}
+					req := &grpcStartSharingRequest{}
+					if err := req.UnmarshalProtoWire(body); err != nil {
+						return nil, fmt.Errorf("error unmarshaling request: %w", err)
+					}
+					if req.Port != serverPort {
+						return nil, errors.New("port does not match serverPort")
+					}
+					if req.Protocol != serverProtocol {
+						return nil, errors.New("protocol does not match serverProtocol")
+					}
+					if req.BrowseURL != fmt.Sprintf("http://localhost:%d", serverPort) {
+						return nil, errors.New("browseURL does not match expected")
+					}
+					events <- (&grpcEventNotification{Port: &grpcPortNotification{
+						Port:       req.Port,
+						ChangeKind: string(PortChangeKindStart),
+					}}).MarshalProtoWire(nil)
+					return (&grpcPort{StreamName: "stream-name", StreamCondition: "stream-condition"}).MarshalProtoWire(nil), nil
+				}
+				grpcServer, session, err := makeMockGRPCSession(invoke, events)
+				if err != nil {
+					t.Fatalf("error creating mock grpc session: %v", err)
+				}
+				return session, nil, func() { grpcServer.Close() }
+			},
+		},
 	}
-	ctx := context.Background()
 
-	go func() {
-		notif := <-sendNotification
-		_, _ = notif.conn.DispatchCall(context.Background(), "serverSharing.sharingSucceeded", notif)
-	}()
-
-	done := make(chan error)
-	go func() {
-		streamID, err := session.StartSharing(ctx, serverProtocol, serverPort)
-		if err != nil {
-			done <- fmt.Errorf("error sharing server: %w", err)
-		}
-		if streamID.name == "" || streamID.condition == "" {
-			done <- errors.New("stream name or condition is blank")
-		}
-		done <- nil
-	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, errc, cleanup := tt.newSession(t)
+			defer cleanup()
+			ctx := context.Background()
+
+			events, unsubscribe := session.Subscribe(ctx, PortEvents)
+			defer unsubscribe()
+
+			done := make(chan error)
+			go func() {
+				streamID, err := session.StartSharing(ctx, serverProtocol, serverPort)
+				if err != nil {
+					done <- fmt.Errorf("error sharing server: %w", err)
+					return
+				}
+				if streamID.name == "" || streamID.condition == "" {
+					done <- errors.New("stream name or condition is blank")
+					return
+				}
+				done <- nil
+			}()
+
+			select {
+			case err := <-errc:
+				t.Errorf("error from server: %v", err)
+			case err := <-done:
+				if err != nil {
+					t.Errorf("error from client: %v", err)
+				}
+			}
 
-	select {
-	case err := <-testServer.Err():
-		t.Errorf("error from server: %v", err)
-	case err := <-done:
-		if err != nil {
-			t.Errorf("error from client: %v", err)
-		}
+			select {
+			case evt := <-events:
+				if evt.Port == nil || evt.Port.Port != serverPort || evt.Port.ChangeKind != PortChangeKindStart {
+					t.Errorf("unexpected port event: %+v", evt.Port)
+				}
+			case <-time.After(time.Second):
+				t.Error("timed out waiting for sharingSucceeded event")
+			}
+		})
 	}
 }
 
@@ -129,92 +198,177 @@ func TestServerGetSharedServers(t *testing.T) {
 		StreamName:      "stream-name",
 		StreamCondition: "stream-condition",
 	}
-	getSharedServers := func(conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
-		return []*Port{&sharedServer}, nil
-	}
-	testServer, session, err := makeMockSession(
-		livesharetest.WithService("serverSharing.getSharedServers", getSharedServers),
-	)
-	if err != nil {
-		t.Errorf("error creating mock session: %v", err)
+
+	tests := []sessionTransportCase{
+		{
+			name: "relay",
+			newSession: func(t *testing.T) (*Session, <-chan error, func()) {
+				getSharedServers := func(conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+					return []*Port{&sharedServer}, nil
+				}
+				testServer, session, err := makeMockSession(
+					livesharetest.WithService("serverSharing.getSharedServers", getSharedServers),
+				)
+				if err != nil {
+					t.Fatalf("error creating mock session: %v", err)
+				}
+				return session, testServer.Err(), func() { testServer.Close() }
+			},
+		},
+		{
+			name: "grpc",
+			newSession: func(t *testing.T) (*Session, <-chan error, func()) {
+				invoke := func(ctx context.Context, method string, body []byte) ([]byte, error) {
+					if resp, ok := grpcJoinWorkspace(method); ok {
+						return resp, nil
+					}
+					if method != "/liveshare.Liveshare/GetSharedServers" {
+						return nil, fmt.Errorf("unexpected method %q", method)
+					}
+					resp := &grpcGetSharedServersResponse{Ports: []*grpcPort{portToGRPC(&sharedServer)}}
+					return resp.MarshalProtoWire(nil), nil
+				}
+				grpcServer, session, err := makeMockGRPCSession(invoke, nil)
+				if err != nil {
+					t.Fatalf("error creating mock grpc session: %v", err)
+				}
+				return session, nil, func() { grpcServer.Close() }
+			},
+		},
 	}
-	defer testServer.Close()
-	ctx := context.Background()
-	done := make(chan error)
-	go func() {
-		ports, err := session.GetSharedServers(ctx)
-		if err != nil {
-			done <- fmt.Errorf("error getting shared servers: %w", err)
-		}
-		if len(ports) < 1 {
-			done <- errors.New("not enough ports returned")
-		}
-		if ports[0].SourcePort != sharedServer.SourcePort {
-			done <- errors.New("source port does not match")
-		}
-		if ports[0].StreamName != sharedServer.StreamName {
-			done <- errors.New("stream name does not match")
-		}
-		if ports[0].StreamCondition != sharedServer.StreamCondition {
-			done <- errors.New("stream condiion does not match")
-		}
-		done <- nil
-	}()
 
-	select {
-	case err := <-testServer.Err():
-		t.Errorf("error from server: %v", err)
-	case err := <-done:
-		if err != nil {
-			t.Errorf("error from client: %v", err)
-		}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, errc, cleanup := tt.newSession(t)
+			defer cleanup()
+			ctx := context.Background()
+
+			done := make(chan error)
+			go func() {
+				ports, err := session.GetSharedServers(ctx)
+				if err != nil {
+					done <- fmt.Errorf("error getting shared servers: %w", err)
+					return
+				}
+				if len(ports) < 1 {
+					done <- errors.New("not enough ports returned")
+					return
+				}
+				if ports[0].SourcePort != sharedServer.SourcePort {
+					done <- errors.New("source port does not match")
+					return
+				}
+				if ports[0].StreamName != sharedServer.StreamName {
+					done <- errors.New("stream name does not match")
+					return
+				}
+				if ports[0].StreamCondition != sharedServer.StreamCondition {
+					done <- errors.New("stream condiion does not match")
+					return
+				}
+				done <- nil
+			}()
+
+			select {
+			case err := <-errc:
+				t.Errorf("error from server: %v", err)
+			case err := <-done:
+				if err != nil {
+					t.Errorf("error from client: %v", err)
+				}
+			}
+		})
 	}
 }
 
 func TestServerUpdateSharedServerPrivacy(t *testing.T) {
-	updateSharedVisibility := func(conn *jsonrpc2.Conn, rpcReq *jsonrpc2.Request) (interface{}, error) {
-		var req []interface{}
-		if err := json.Unmarshal(*rpcReq.Params, &req); err != nil {
-			return nil, fmt.Errorf("unmarshal req: %w", err)
-		}
-		if len(req) < 2 {
-			return nil, errors.New("request arguments is less than 2")
-		}
-		if port, ok := req[0].(float64); ok {
-			if port != 80.0 {
-				return nil, errors.New("port param is not expected value")
-			}
-		} else {
-			return nil, errors.New("port param is not a float64")
-		}
-		if privacy, ok := req[1].(string); ok {
-			if privacy != "public" {
-				return nil, fmt.Errorf("expected privacy param to be public but got %q", privacy)
-			}
-		} else {
-			return nil, fmt.Errorf("expected privacy param to be a bool but go %T", req[1])
-		}
-		return nil, nil
-	}
-	testServer, session, err := makeMockSession(
-		livesharetest.WithService("serverSharing.updateSharedServerPrivacy", updateSharedVisibility),
-	)
-	if err != nil {
-		t.Errorf("creating mock session: %v", err)
+	tests := []sessionTransportCase{
+		{
+			name: "relay",
+			newSession: func(t *testing.T) (*Session, <-chan error, func()) {
+				updateSharedVisibility := func(conn *jsonrpc2.Conn, rpcReq *jsonrpc2.Request) (interface{}, error) {
+					var req []interface{}
+					if err := json.Unmarshal(*rpcReq.Params, &req); err != nil {
+						return nil, fmt.Errorf("unmarshal req: %w", err)
+					}
+					if len(req) < 2 {
+						return nil, errors.New("request arguments is less than 2")
+					}
+					if port, ok := req[0].(float64); ok {
+						if port != 80.0 {
+							return nil, errors.New("port param is not expected value")
+						}
+					} else {
+						return nil, errors.New("port param is not a float64")
+					}
+					if privacy, ok := req[1].(string); ok {
+						if privacy != "public" {
+							return nil, fmt.Errorf("expected privacy param to be public but got %q", privacy)
+						}
+					} else {
+						return nil, fmt.Errorf("expected privacy param to be a string but go %T", req[1])
+					}
+					return nil, nil
+				}
+				testServer, session, err := makeMockSession(
+					livesharetest.WithService("serverSharing.updateSharedServerPrivacy", updateSharedVisibility),
+				)
+				if err != nil {
+					t.Fatalf("creating mock session: %v", err)
+				}
+				return session, testServer.Err(), func() { testServer.Close() }
+			},
+		},
+		{
+			name: "grpc",
+			newSession: func(t *testing.T) (*Session, <-chan error, func()) {
+				invoke := func(ctx context.Context, method string, body []byte) ([]byte, error) {
+					if resp, ok := grpcJoinWorkspace(method); ok {
+						return resp, nil
+					}
+					if method != "/liveshare.Liveshare/UpdateSharedServerPrivacy" {
+						return nil, fmt.Errorf("unexpected method %q", method)
+					}
+					req := &grpcUpdateSharedServerPrivacyRequest{}
+					if err := req.UnmarshalProtoWire(body); err != nil {
+						return nil, fmt.Errorf("error unmarshaling request: %w", err)
+					}
+					if req.Port != 80 {
+						return nil, errors.New("port param is not expected value")
+					}
+					if req.Visibility != "public" {
+						return nil, fmt.Errorf("expected privacy param to be public but got %q", req.Visibility)
+					}
+					return (&grpcEmpty{}).MarshalProtoWire(nil), nil
+				}
+				grpcServer, session, err := makeMockGRPCSession(invoke, nil)
+				if err != nil {
+					t.Fatalf("error creating mock grpc session: %v", err)
+				}
+				return session, nil, func() { grpcServer.Close() }
+			},
+		},
 	}
-	defer testServer.Close()
-	ctx := context.Background()
-	done := make(chan error)
-	go func() {
-		done <- session.UpdateSharedServerPrivacy(ctx, 80, "public")
-	}()
-	select {
-	case err := <-testServer.Err():
-		t.Errorf("error from server: %v", err)
-	case err := <-done:
-		if err != nil {
-			t.Errorf("error from client: %v", err)
-		}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, errc, cleanup := tt.newSession(t)
+			defer cleanup()
+			ctx := context.Background()
+
+			done := make(chan error)
+			go func() {
+				done <- session.UpdateSharedServerPrivacy(ctx, 80, "public")
+			}()
+			select {
+			case err := <-errc:
+				t.Errorf("error from server: %v", err)
+			case err := <-done:
+				if err != nil {
+					t.Errorf("error from client: %v", err)
+				}
+			}
+		})
 	}
 }
 
@@ -255,152 +409,322 @@ func TestKeepAliveNonBlocking(t *testing.T) {
 }
 
 func TestNotifyHostOfActivity(t *testing.T) {
-	notifyHostOfActivity := func(conn *jsonrpc2.Conn, rpcReq *jsonrpc2.Request) (interface{}, error) {
-		var req []interface{}
-		if err := json.Unmarshal(*rpcReq.Params, &req); err != nil {
-			return nil, fmt.Errorf("unmarshal req: %w", err)
+	tests := []sessionTransportCase{
+		{
+			name: "relay",
+			newSession: func(t *testing.T) (*Session, <-chan error, func()) {
+				notifyHostOfActivity := func(conn *jsonrpc2.Conn, rpcReq *jsonrpc2.Request) (interface{}, error) {
+					var req []interface{}
+					if err := json.Unmarshal(*rpcReq.Params, &req); err != nil {
+						return nil, fmt.Errorf("unmarshal req: %w", err)
+					}
+					if len(req) < 2 {
+						return nil, errors.New("request arguments is less than 2")
+					}
+
+					if clientName, ok := req[0].(string); ok {
+						if clientName != mockClientName {
+							return nil, fmt.Errorf(
+								"unexpected clientName param, expected: %q, got: %q", mockClientName, clientName,
+							)
+						}
+					} else {
+						return nil, errors.New("clientName param is not a string")
+					}
+
+					if acs, ok := req[1].([]interface{}); ok {
+						if fmt.Sprintf("%s", acs) != "[input]" {
+							return nil, fmt.Errorf("unexpected activities param, expected: [input], got: %s", acs)
+						}
+					} else {
+						return nil, errors.New("activities param is not a slice")
+					}
+
+					return nil, nil
+				}
+				svc := livesharetest.WithService(
+					"ICodespaceHostService.notifyCodespaceOfClientActivity", notifyHostOfActivity,
+				)
+				testServer, session, err := makeMockSession(svc)
+				if err != nil {
+					t.Fatalf("creating mock session: %v", err)
+				}
+				return session, testServer.Err(), func() { testServer.Close() }
+			},
+		},
+		{
+			name: "grpc",
+			newSession: func(t *testing.T) (*Session, <-chan error, func()) {
+				invoke := func(ctx context.Context, method string, body []byte) ([]byte, error) {
+					if resp, ok := grpcJoinWorkspace(method); ok {
+						return resp, nil
+					}
+					if method != "/liveshare.Liveshare/NotifyCodespaceOfClientActivity" {
+						return nil, fmt.Errorf("unexpected method %q", method)
+					}
+					req := &grpcNotifyActivityRequest{}
+					if err := req.UnmarshalProtoWire(body); err != nil {
+						return nil, fmt.Errorf("error unmarshaling request: %w", err)
+					}
+					if req.ClientName != mockClientName {
+						return nil, fmt.Errorf(
+							"unexpected clientName param, expected: %q, got: %q", mockClientName, req.ClientName,
+						)
+					}
+					if fmt.Sprintf("%v", req.Activities) != "[input]" {
+						return nil, fmt.Errorf("unexpected activities param, expected: [input], got: %v", req.Activities)
+					}
+					return (&grpcEmpty{}).MarshalProtoWire(nil), nil
+				}
+				grpcServer, session, err := makeMockGRPCSession(invoke, nil)
+				if err != nil {
+					t.Fatalf("error creating mock grpc session: %v", err)
+				}
+				return session, nil, func() { grpcServer.Close() }
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, errc, cleanup := tt.newSession(t)
+			defer cleanup()
+			ctx := context.Background()
+
+			done := make(chan error)
+			go func() {
+				done <- session.notifyHostOfActivity(ctx, "input")
+			}()
+			select {
+			case err := <-errc:
+				t.Errorf("error from server: %v", err)
+			case err := <-done:
+				if err != nil {
+					t.Errorf("error from client: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// TestSessionHeartbeat covers the heartbeat loop's three distinct
+// regimes: no activity, activity reported twice in succession (each
+// waited on until its request lands), and activity across a forced
+// transport disconnect.
+func TestSessionHeartbeat(t *testing.T) {
+	t.Run("idle", func(t *testing.T) {
+		testServer, session, err := makeMockSession()
+		if err != nil {
+			t.Fatalf("error creating mock session: %v", err)
 		}
-		if len(req) < 2 {
-			return nil, errors.New("request arguments is less than 2")
+		defer testServer.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go session.heartbeat(ctx, 20*time.Millisecond)
+
+		select {
+		case err := <-testServer.Err():
+			t.Fatalf("unexpected request from idle heartbeat: %v", err)
+		case <-time.After(100 * time.Millisecond):
+			// no KeepAlive was ever called, so nothing should have been sent.
 		}
+	})
 
-		if clientName, ok := req[0].(string); ok {
-			if clientName != mockClientName {
-				return nil, fmt.Errorf(
-					"unexpected clientName param, expected: %q, got: %q", mockClientName, clientName,
-				)
+	t.Run("bursty", func(t *testing.T) {
+		var (
+			requestsMu sync.Mutex
+			requests   int
+			wg         sync.WaitGroup
+		)
+		wg.Add(1)
+		notifyHostOfActivity := func(conn *jsonrpc2.Conn, rpcReq *jsonrpc2.Request) (interface{}, error) {
+			defer wg.Done()
+			requestsMu.Lock()
+			requests++
+			requestsMu.Unlock()
+
+			var req []interface{}
+			if err := json.Unmarshal(*rpcReq.Params, &req); err != nil {
+				return nil, fmt.Errorf("unmarshal req: %w", err)
+			}
+			if len(req) < 2 {
+				return nil, errors.New("request arguments is less than 2")
 			}
-		} else {
-			return nil, errors.New("clientName param is not a string")
-		}
 
-		if acs, ok := req[1].([]interface{}); ok {
-			if fmt.Sprintf("%s", acs) != "[input]" {
-				return nil, fmt.Errorf("unexpected activities param, expected: [input], got: %s", acs)
+			if clientName, ok := req[0].(string); ok {
+				if clientName != mockClientName {
+					return nil, fmt.Errorf(
+						"unexpected clientName param, expected: %q, got: %q", mockClientName, clientName,
+					)
+				}
+			} else {
+				return nil, errors.New("clientName param is not a string")
 			}
-		} else {
-			return nil, errors.New("activities param is not a slice")
-		}
 
-		return nil, nil
-	}
-	svc := livesharetest.WithService(
-		"ICodespaceHostService.notifyCodespaceOfClientActivity", notifyHostOfActivity,
-	)
-	testServer, session, err := makeMockSession(svc)
-	if err != nil {
-		t.Fatalf("creating mock session: %v", err)
-	}
-	defer testServer.Close()
-	ctx := context.Background()
-	done := make(chan error)
-	go func() {
-		done <- session.notifyHostOfActivity(ctx, "input")
-	}()
-	select {
-	case err := <-testServer.Err():
-		t.Errorf("error from server: %v", err)
-	case err := <-done:
+			if acs, ok := req[1].([]interface{}); ok {
+				if fmt.Sprintf("%s", acs) != "[input]" {
+					return nil, fmt.Errorf("unexpected activities param, expected: [input], got: %s", acs)
+				}
+			} else {
+				return nil, errors.New("activities param is not a slice")
+			}
+
+			return nil, nil
+		}
+		svc := livesharetest.WithService(
+			"ICodespaceHostService.notifyCodespaceOfClientActivity", notifyHostOfActivity,
+		)
+		testServer, session, err := makeMockSession(svc)
 		if err != nil {
-			t.Errorf("error from client: %v", err)
+			t.Fatalf("creating mock session: %v", err)
 		}
-	}
-}
+		defer testServer.Close()
 
-func TestSessionHeartbeat(t *testing.T) {
-	var (
-		requestsMu sync.Mutex
-		requests   int
-		wg         sync.WaitGroup
-	)
-	wg.Add(1)
-	notifyHostOfActivity := func(conn *jsonrpc2.Conn, rpcReq *jsonrpc2.Request) (interface{}, error) {
-		defer wg.Done()
-		requestsMu.Lock()
-		requests++
-		requestsMu.Unlock()
-
-		var req []interface{}
-		if err := json.Unmarshal(*rpcReq.Params, &req); err != nil {
-			return nil, fmt.Errorf("unmarshal req: %w", err)
-		}
-		if len(req) < 2 {
-			return nil, errors.New("request arguments is less than 2")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		done := make(chan struct{})
+
+		logger := newMockLogger()
+		session.logger = logger
+
+		go session.heartbeat(ctx, 50*time.Millisecond)
+		go func() {
+			session.KeepAlive("input")
+			wg.Wait()
+			wg.Add(1)
+			session.KeepAlive("input")
+			wg.Wait()
+			done <- struct{}{}
+		}()
+
+		select {
+		case err := <-testServer.Err():
+			t.Errorf("error from server: %v", err)
+		case <-done:
+			activityCount := strings.Count(logger.String(), "input")
+			// by design KeepAlive can drop requests, and therefore there is zero guarantee
+			// that we actually get two requests if the network happened to be slow (rarely)
+			// during testing.
+			if activityCount != 1 && activityCount != 2 {
+				t.Errorf("unexpected number of activities, expected: 1-2, got: %d", activityCount)
+			}
+
+			requestsMu.Lock()
+			rc := requests
+			requestsMu.Unlock()
+			// though this could be also dropped, the sync.WaitGroup above guarantees
+			// that it gets called a second time.
+			if rc != 2 {
+				t.Errorf("unexpected number of requests, expected: 2, got: %d", requests)
+			}
 		}
+	})
 
-		if clientName, ok := req[0].(string); ok {
-			if clientName != mockClientName {
-				return nil, fmt.Errorf(
-					"unexpected clientName param, expected: %q, got: %q", mockClientName, clientName,
-				)
+	t.Run("forced disconnect mid-flight", func(t *testing.T) {
+		var activityMu sync.Mutex
+		var activity []string
+		notifyHostOfActivity := func(conn *jsonrpc2.Conn, rpcReq *jsonrpc2.Request) (interface{}, error) {
+			var req []interface{}
+			if err := json.Unmarshal(*rpcReq.Params, &req); err != nil {
+				return nil, fmt.Errorf("unmarshal req: %w", err)
 			}
-		} else {
-			return nil, errors.New("clientName param is not a string")
+			if len(req) < 2 {
+				return nil, errors.New("request arguments is less than 2")
+			}
+			acs, ok := req[1].([]interface{})
+			if !ok {
+				return nil, errors.New("activities param is not a slice")
+			}
+			activityMu.Lock()
+			for _, a := range acs {
+				activity = append(activity, fmt.Sprintf("%v", a))
+			}
+			activityMu.Unlock()
+			return nil, nil
+		}
+		testServer, session, err := makeMockSession(
+			livesharetest.WithService("ICodespaceHostService.notifyCodespaceOfClientActivity", notifyHostOfActivity),
+		)
+		if err != nil {
+			t.Fatalf("error creating mock session: %v", err)
 		}
+		defer testServer.Close()
 
-		if acs, ok := req[1].([]interface{}); ok {
-			if fmt.Sprintf("%s", acs) != "[input]" {
-				return nil, fmt.Errorf("unexpected activities param, expected: [input], got: %s", acs)
+		// Make the first couple of redials fail before falling through
+		// to the real reconnect func, so the backoff/retry loop in
+		// reconnectWithBackoff is actually exercised rather than
+		// succeeding on the very first attempt.
+		const failuresBeforeSuccess = 2
+		var failures int
+		realReconnect := session.reconnect
+		session.reconnect = func(ctx context.Context) (RPCTransport, error) {
+			failures++
+			if failures <= failuresBeforeSuccess {
+				return nil, fmt.Errorf("synthetic reconnect failure %d", failures)
 			}
-		} else {
-			return nil, errors.New("activities param is not a slice")
+			return realReconnect(ctx)
 		}
 
-		return nil, nil
-	}
-	svc := livesharetest.WithService(
-		"ICodespaceHostService.notifyCodespaceOfClientActivity", notifyHostOfActivity,
-	)
-	testServer, session, err := makeMockSession(svc)
-	if err != nil {
-		t.Fatalf("creating mock session: %v", err)
-	}
-	defer testServer.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+		if err := session.Start(ctx); err != nil {
+			t.Fatalf("error starting session: %v", err)
+		}
+		defer session.Stop()
 
-	done := make(chan struct{})
+		// Simulate a relay hiccup mid-flight: queue activity, then force
+		// the transport closed out from under the session without going
+		// through Stop, so it has to ride out the outage and resume.
+		session.KeepAlive("input")
+		if err := session.getTransport().Close(); err != nil {
+			t.Fatalf("error forcing transport closed: %v", err)
+		}
 
-	logger := newMockLogger()
-	session.logger = logger
+		deadline := time.After(5 * time.Second)
+		for {
+			if session.Stats().Reconnects > 0 {
+				break
+			}
+			select {
+			case <-deadline:
+				t.Fatalf("session did not reconnect in time, stats: %+v", session.Stats())
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
 
-	go session.heartbeat(ctx, 50*time.Millisecond)
-	go func() {
-		session.KeepAlive("input")
-		wg.Wait()
-		wg.Add(1)
-		session.KeepAlive("input")
-		wg.Wait()
-		done <- struct{}{}
-	}()
-
-	select {
-	case err := <-testServer.Err():
-		t.Errorf("error from server: %v", err)
-	case <-done:
-		activityCount := strings.Count(logger.String(), "input")
-		// by design KeepAlive can drop requests, and therefore there is zero guarantee
-		// that we actually get two requests if the network happened to be slow (rarely)
-		// during testing.
-		if activityCount != 1 && activityCount != 2 {
-			t.Errorf("unexpected number of activities, expected: 1-2, got: %d", activityCount)
+		if got := session.Stats().ReconnectAttempts; got < failuresBeforeSuccess+1 {
+			t.Errorf("expected at least %d reconnect attempts, got %d", failuresBeforeSuccess+1, got)
 		}
 
-		requestsMu.Lock()
-		rc := requests
-		requestsMu.Unlock()
-		// though this could be also dropped, the sync.WaitGroup above guarantees
-		// that it gets called a second time.
-		if rc != 2 {
-			t.Errorf("unexpected number of requests, expected: 2, got: %d", requests)
+		deadline = time.After(5 * time.Second)
+		for {
+			activityMu.Lock()
+			got := len(activity)
+			activityMu.Unlock()
+			if got > 0 {
+				break
+			}
+			select {
+			case <-deadline:
+				t.Fatalf("activity queued during the outage was never resumed")
+			case <-time.After(10 * time.Millisecond):
+			}
 		}
-		return
-	}
+
+		activityMu.Lock()
+		defer activityMu.Unlock()
+		if activity[0] != "input" {
+			t.Errorf("unexpected resumed activity list: %v", activity)
+		}
+	})
 }
 
 func TestRebuild(t *testing.T) {
-	tests := []struct {
+	rebuildCases := []struct {
 		fullRebuild bool
 		rpcService  string
 	}{
@@ -414,30 +738,77 @@ func TestRebuild(t *testing.T) {
 		},
 	}
 
-	for _, tt := range tests {
-		t.Logf("RPC service: %s", tt.rpcService)
-		t.Logf("full rebuild: %t", tt.fullRebuild)
-
-		requestCount := 0
-		rebuildContainer := func(conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
-			requestCount++
-			return true, nil
-		}
-		testServer, session, err := makeMockSession(
-			livesharetest.WithService(tt.rpcService, rebuildContainer),
-		)
-		if err != nil {
-			t.Errorf("creating mock session: %v", err)
-		}
-		defer testServer.Close()
-
-		err = session.RebuildContainer(context.Background(), tt.fullRebuild)
-		if err != nil {
-			t.Errorf("rebuilding codespace via mock session: %v", err)
+	for _, rc := range rebuildCases {
+		var relayRequests, grpcRequests int
+
+		tests := []sessionTransportCase{
+			{
+				name: "relay",
+				newSession: func(t *testing.T) (*Session, <-chan error, func()) {
+					rebuildContainer := func(conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+						relayRequests++
+						return true, nil
+					}
+					testServer, session, err := makeMockSession(
+						livesharetest.WithService(rc.rpcService, rebuildContainer),
+					)
+					if err != nil {
+						t.Fatalf("creating mock session: %v", err)
+					}
+					return session, testServer.Err(), func() { testServer.Close() }
+				},
+			},
+			{
+				name: "grpc",
+				newSession: func(t *testing.T) (*Session, <-chan error, func()) {
+					invoke := func(ctx context.Context, method string, body []byte) ([]byte, error) {
+						if resp, ok := grpcJoinWorkspace(method); ok {
+							return resp, nil
+						}
+						if method != "/liveshare.Liveshare/RebuildContainer" {
+							return nil, fmt.Errorf("unexpected method %q", method)
+						}
+						req := &grpcRebuildContainerRequest{}
+						if err := req.UnmarshalProtoWire(body); err != nil {
+							return nil, fmt.Errorf("error unmarshaling request: %w", err)
+						}
+						if req.FullRebuild != rc.fullRebuild {
+							return nil, fmt.Errorf("expected full_rebuild=%t, got %t", rc.fullRebuild, req.FullRebuild)
+						}
+						grpcRequests++
+						return (&grpcRebuildContainerResponse{Accepted: true}).MarshalProtoWire(nil), nil
+					}
+					grpcServer, session, err := makeMockGRPCSession(invoke, nil)
+					if err != nil {
+						t.Fatalf("error creating mock grpc session: %v", err)
+					}
+					return session, nil, func() { grpcServer.Close() }
+				},
+			},
 		}
 
-		if requestCount == 0 {
-			t.Errorf("no requests were made")
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/full=%t", tt.name, rc.fullRebuild), func(t *testing.T) {
+				session, errc, cleanup := tt.newSession(t)
+				defer cleanup()
+
+				done := make(chan error)
+				go func() {
+					done <- session.RebuildContainer(context.Background(), rc.fullRebuild)
+				}()
+				select {
+				case err := <-errc:
+					t.Errorf("error from server: %v", err)
+				case err := <-done:
+					if err != nil {
+						t.Errorf("rebuilding codespace via mock session: %v", err)
+					}
+				}
+
+				if relayRequests+grpcRequests == 0 {
+					t.Errorf("no requests were made")
+				}
+			})
 		}
 	}
 }
@@ -0,0 +1,46 @@
+package liveshare
+
+import (
+	"io"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsConn adapts a *websocket.Conn to the net.Conn interface required by
+// the SSH client, presenting the sequence of binary websocket messages
+// as a single continuous byte stream.
+type wsConn struct {
+	*websocket.Conn
+	reader io.Reader
+}
+
+func (c *wsConn) Read(b []byte) (int, error) {
+	for c.reader == nil {
+		_, r, err := c.Conn.NextReader()
+		if err != nil {
+			return 0, err
+		}
+		c.reader = r
+	}
+	n, err := c.reader.Read(b)
+	if err == io.EOF {
+		c.reader = nil
+		err = nil
+	}
+	return n, err
+}
+
+func (c *wsConn) Write(b []byte) (int, error) {
+	if err := c.Conn.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *wsConn) SetDeadline(t time.Time) error {
+	if err := c.Conn.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.Conn.SetWriteDeadline(t)
+}
@@ -0,0 +1,103 @@
+package liveshare
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshSession is the SSH tunnel opened to a codespace through the Live
+// Share relay. Session traffic (jsonrpc2) is carried over its channel.
+type sshSession struct {
+	client  *ssh.Client
+	channel ssh.Channel
+}
+
+// newSSHSession dials the Live Share relay described by opts, performs
+// the SSH handshake, and opens the "session" channel used for RPC.
+func newSSHSession(ctx context.Context, opts Options) (*sshSession, error) {
+	relayURL, err := relayWebSocketURL(opts.RelayEndpoint, opts.RelaySAS)
+	if err != nil {
+		return nil, fmt.Errorf("error building relay url: %w", err)
+	}
+
+	dialer := &websocket.Dialer{TLSClientConfig: opts.TLSConfig}
+	conn, _, err := dialer.DialContext(ctx, relayURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error dialing relay: %w", err)
+	}
+
+	hostKeyCallback, err := fixedHostKeyCallback(opts.HostPublicKeys)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing host public keys: %w", err)
+	}
+
+	clientConn, chans, reqs, err := ssh.NewClientConn(&wsConn{Conn: conn}, relayURL, &ssh.ClientConfig{
+		User:            opts.SessionID,
+		Auth:            []ssh.AuthMethod{ssh.Password(opts.SessionToken)},
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error establishing ssh connection: %w", err)
+	}
+	client := ssh.NewClient(clientConn, chans, reqs)
+
+	channel, requests, err := client.OpenChannel("session", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error opening ssh channel: %w", err)
+	}
+	go ssh.DiscardRequests(requests)
+
+	return &sshSession{client: client, channel: channel}, nil
+}
+
+func (s *sshSession) Close() error {
+	// The jsonrpc2 connection carried over this channel closes the
+	// channel itself as part of its own shutdown, so this Close is
+	// usually called on an already-closed channel; ignore the resulting
+	// EOF and close the underlying client connection regardless.
+	if err := s.channel.Close(); err != nil && err != io.EOF {
+		return err
+	}
+	return s.client.Close()
+}
+
+// relayWebSocketURL translates the "sb://" Azure Relay endpoint handed
+// out by the Codespaces API into the "wss://" URL actually dialed.
+func relayWebSocketURL(endpoint, sas string) (string, error) {
+	if !strings.HasPrefix(endpoint, "sb://") {
+		return "", fmt.Errorf("unexpected relay endpoint scheme: %q", endpoint)
+	}
+	v := url.Values{}
+	v.Set("sb-hc-token", sas)
+	return "wss://" + strings.TrimPrefix(endpoint, "sb://") + "?" + v.Encode(), nil
+}
+
+// fixedHostKeyCallback accepts only the public keys reported by the
+// Codespaces API for this codespace, rejecting everything else.
+func fixedHostKeyCallback(encodedKeys []string) (ssh.HostKeyCallback, error) {
+	keys := make([]ssh.PublicKey, 0, len(encodedKeys))
+	for _, encoded := range encodedKeys {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing host public key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		marshaled := key.Marshal()
+		for _, k := range keys {
+			if bytes.Equal(k.Marshal(), marshaled) {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid host key: %s", ssh.FingerprintSHA256(key))
+	}, nil
+}
@@ -0,0 +1,132 @@
+package livesharetest
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// rawBytesCodec lets GRPCServer forward a call's encoded message
+// untouched, so it can dispatch generically by method name without
+// understanding the protobuf wire format GRPCTransport's messages are
+// actually encoded in.
+type rawBytesCodec struct{}
+
+func (rawBytesCodec) Name() string { return "bytes" }
+
+func (rawBytesCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("livesharetest: expected []byte, got %T", v)
+	}
+	return b, nil
+}
+
+func (rawBytesCodec) Unmarshal(data []byte, v interface{}) error {
+	out, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("livesharetest: expected *[]byte, got %T", v)
+	}
+	*out = append([]byte(nil), data...)
+	return nil
+}
+
+func init() {
+	encoding.RegisterCodec(rawBytesCodec{})
+}
+
+// GRPCInvoke answers a single unary RPC identified by its full gRPC
+// method name (e.g. "/liveshare.Liveshare/StartSharing"), given the
+// raw protobuf-encoded request body, and returns the raw
+// protobuf-encoded response body.
+type GRPCInvoke func(ctx context.Context, method string, body []byte) ([]byte, error)
+
+// GRPCServer is a minimal in-process stand-in for a gRPC Live Share
+// backend, for exercising GRPCTransport without a real service. Like
+// Server, it has no generated proto stubs: every call, including the
+// streaming Events RPC, is dispatched by full method name through
+// invoke and events, with message bodies passed through as opaque
+// bytes rather than decoded, so this server doesn't need to agree with
+// GRPCTransport on anything beyond the wire bytes themselves.
+type GRPCServer struct {
+	listener *bufconn.Listener
+	server   *grpc.Server
+}
+
+// NewGRPCServer starts a GRPCServer that answers every unary RPC with
+// invoke, and serves events (if non-nil) on the Events stream until it
+// is closed.
+func NewGRPCServer(invoke GRPCInvoke, events <-chan []byte) *GRPCServer {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(
+		grpc.ForceServerCodec(rawBytesCodec{}),
+		grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+			method, ok := grpc.MethodFromServerStream(stream)
+			if !ok {
+				return nil
+			}
+			if method == "/liveshare.Liveshare/Events" {
+				return serveGRPCEvents(stream, events)
+			}
+			var body []byte
+			if err := stream.RecvMsg(&body); err != nil {
+				return err
+			}
+			resp, err := invoke(stream.Context(), method, body)
+			if err != nil {
+				return err
+			}
+			return stream.SendMsg(resp)
+		}),
+	)
+	go srv.Serve(lis)
+	return &GRPCServer{listener: lis, server: srv}
+}
+
+func serveGRPCEvents(stream grpc.ServerStream, events <-chan []byte) error {
+	var body []byte
+	if err := stream.RecvMsg(&body); err != nil {
+		return err
+	}
+	for {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.SendMsg(evt); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// ContextDialer returns a dial function suitable for
+// grpc.WithContextDialer, connecting directly to this in-process
+// server without a real network listener.
+func (s *GRPCServer) ContextDialer() func(context.Context, string) (net.Conn, error) {
+	return func(ctx context.Context, _ string) (net.Conn, error) {
+		return s.listener.DialContext(ctx)
+	}
+}
+
+// DialOptions returns the grpc.DialOption set needed to reach this
+// server via grpc.DialContext(ctx, "bufnet", server.DialOptions()...).
+func (s *GRPCServer) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(s.ContextDialer()),
+	}
+}
+
+// Close shuts down the server and any streams still open against it.
+func (s *GRPCServer) Close() {
+	s.server.Stop()
+}
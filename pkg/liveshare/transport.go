@@ -0,0 +1,29 @@
+package liveshare
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Handler processes a single inbound call or notification for the
+// method it was registered against.
+type Handler func(ctx context.Context, method string, params *json.RawMessage) (interface{}, error)
+
+// RPCTransport abstracts how a Session talks to the host. The default,
+// relayTransport, speaks jsonrpc2 over an SSH tunnel through the Azure
+// Relay used by GitHub-hosted Codespaces; other implementations (e.g.
+// gRPC, for self-hosted backends) can be substituted via Options.Transport.
+type RPCTransport interface {
+	// Call issues method with params and decodes the response into result.
+	Call(ctx context.Context, method string, params, result interface{}) error
+	// Notify issues method with params and discards any response. It may
+	// or may not block until the host has handled the call.
+	Notify(ctx context.Context, method string, params interface{}) error
+	// Handle registers h to process inbound calls and notifications for method.
+	Handle(method string, h Handler)
+	// DisconnectNotify returns a channel that is closed when the
+	// underlying connection is lost.
+	DisconnectNotify() <-chan struct{}
+	// Close shuts down the transport and any connection it owns.
+	Close() error
+}